@@ -0,0 +1,38 @@
+// Command token is the CLI entry point for this module's code-generation
+// tooling.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/HelgeSverre/token/internal/enumgen"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "enumgen":
+		dir := "."
+		if len(os.Args) > 2 {
+			dir = os.Args[2]
+		}
+		if err := enumgen.Run(dir); err != nil {
+			fmt.Fprintln(os.Stderr, "token enumgen:", err)
+			os.Exit(1)
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: token <command> [args]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  enumgen [dir]   generate Stringer/Parse/JSON methods for token:enum types")
+}
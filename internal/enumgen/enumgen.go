@@ -0,0 +1,230 @@
+// Package enumgen generates Stringer, Parse<Type>, and JSON (un)marshaler
+// methods for iota-based enums tagged with a "// token:enum" comment. It
+// backs the `token enumgen` subcommand invoked via go:generate.
+package enumgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const enumTag = "token:enum"
+
+// enumType is one token:enum-tagged type and the names of its constants, in
+// source order. The constants are referenced by identifier in the
+// generated code, so their actual (possibly iota-computed) values are
+// resolved by the Go compiler, not by enumgen.
+type enumType struct {
+	Name    string
+	Members []string
+}
+
+// Run scans the Go package rooted at dir for token:enum-tagged types and
+// writes a "<lowercased type>_string.go" file per type containing its
+// generated methods.
+func Run(dir string) error {
+	fset := token.NewFileSet()
+	filter := func(fi os.FileInfo) bool { return !strings.HasSuffix(fi.Name(), "_test.go") }
+	pkgs, err := parser.ParseDir(fset, dir, filter, parser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", dir, err)
+	}
+
+	for name, pkg := range pkgs {
+		if strings.HasSuffix(name, "_test") {
+			continue
+		}
+		if err := generatePackage(dir, pkg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func generatePackage(dir string, pkg *ast.Package) error {
+	names := make([]string, 0, len(pkg.Files))
+	for name := range pkg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	files := make([]*ast.File, 0, len(names))
+	for _, name := range names {
+		files = append(files, pkg.Files[name])
+	}
+
+	for _, typeName := range findTaggedTypes(files) {
+		members := collectMembers(files, typeName)
+		if len(members) == 0 {
+			continue
+		}
+		src := render(pkg.Name, enumType{Name: typeName, Members: members})
+		outPath := filepath.Join(dir, strings.ToLower(typeName)+"_string.go")
+		if err := os.WriteFile(outPath, src, 0o644); err != nil {
+			return fmt.Errorf("writing %s: %w", outPath, err)
+		}
+	}
+	return nil
+}
+
+// findTaggedTypes returns the names of all types declared with a trailing
+// "// token:enum" comment, in declaration order.
+func findTaggedTypes(files []*ast.File) []string {
+	var names []string
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok || ts.Comment == nil || !hasEnumTag(ts.Comment) {
+					continue
+				}
+				names = append(names, ts.Name.Name)
+			}
+		}
+	}
+	return names
+}
+
+func hasEnumTag(cg *ast.CommentGroup) bool {
+	for _, c := range cg.List {
+		if strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) == enumTag {
+			return true
+		}
+	}
+	return false
+}
+
+// collectMembers returns, in source order, the names of every constant
+// declared with typeName, following Go's rule that a spec with neither a
+// type nor values in a const block repeats the type of the spec before it
+// (which is how `1 << iota` style blocks stay typed across their iota
+// chain); a spec with values but no type is untyped.
+func collectMembers(files []*ast.File, typeName string) []string {
+	var members []string
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.CONST {
+				continue
+			}
+			lastType := ""
+			for _, spec := range gd.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				switch {
+				case vs.Type != nil:
+					if ident, ok := vs.Type.(*ast.Ident); ok {
+						lastType = ident.Name
+					} else {
+						lastType = ""
+					}
+				case len(vs.Values) > 0:
+					lastType = ""
+				}
+				if lastType != typeName {
+					continue
+				}
+				for _, name := range vs.Names {
+					if name.Name == "_" {
+						continue
+					}
+					members = append(members, name.Name)
+				}
+			}
+		}
+	}
+	return members
+}
+
+// render emits the generated source for et: a name lookup table, its
+// reverse, and the Stringer/Parse/JSON methods built on top of them.
+func render(pkgName string, et enumType) []byte {
+	recv := strings.ToLower(et.Name[:1])
+	field := lowerFirst(et.Name)
+
+	nameToText := make([][2]string, len(et.Members))
+	textToName := make([][2]string, len(et.Members))
+	for i, m := range et.Members {
+		text := fmt.Sprintf("%q", friendlyName(et.Name, m))
+		nameToText[i] = [2]string{m, text}
+		textToName[i] = [2]string{text, m}
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by token enumgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkgName)
+	b.WriteString("import (\n\t\"encoding/json\"\n\t\"fmt\"\n)\n\n")
+
+	fmt.Fprintf(&b, "var %sNames = map[%s]string{\n", field, et.Name)
+	writeAlignedEntries(&b, nameToText)
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(&b, "var %sValues = map[string]%s{\n", field, et.Name)
+	writeAlignedEntries(&b, textToName)
+	b.WriteString("}\n\n")
+
+	b.WriteString("// String implements fmt.Stringer.\n")
+	fmt.Fprintf(&b, "func (%s %s) String() string {\n", recv, et.Name)
+	fmt.Fprintf(&b, "\tif name, ok := %sNames[%s]; ok {\n\t\treturn name\n\t}\n\treturn \"unknown\"\n}\n\n", field, recv)
+
+	fmt.Fprintf(&b, "// Parse%s is the reverse of String.\n", et.Name)
+	fmt.Fprintf(&b, "func Parse%s(s string) (%s, error) {\n", et.Name, et.Name)
+	fmt.Fprintf(&b, "\tif v, ok := %sValues[s]; ok {\n\t\treturn v, nil\n\t}\n", field)
+	fmt.Fprintf(&b, "\treturn 0, fmt.Errorf(\"%%w: %%q is not a valid %s\", ErrInvalidInput, s)\n}\n\n", et.Name)
+
+	b.WriteString("// MarshalJSON implements json.Marshaler.\n")
+	fmt.Fprintf(&b, "func (%s %s) MarshalJSON() ([]byte, error) {\n\treturn json.Marshal(%s.String())\n}\n\n", recv, et.Name, recv)
+
+	b.WriteString("// UnmarshalJSON implements json.Unmarshaler.\n")
+	fmt.Fprintf(&b, "func (%s *%s) UnmarshalJSON(data []byte) error {\n", recv, et.Name)
+	b.WriteString("\tvar str string\n\tif err := json.Unmarshal(data, &str); err != nil {\n\t\treturn err\n\t}\n")
+	fmt.Fprintf(&b, "\tv, err := Parse%s(str)\n\tif err != nil {\n\t\treturn err\n\t}\n", et.Name)
+	fmt.Fprintf(&b, "\t*%s = v\n\treturn nil\n}\n", recv)
+
+	return []byte(b.String())
+}
+
+// writeAlignedEntries writes one "key: value," line per pair, padding keys
+// so the values line up in a column the way gofmt aligns map literals.
+func writeAlignedEntries(b *strings.Builder, pairs [][2]string) {
+	maxKey := 0
+	for _, p := range pairs {
+		if len(p[0]) > maxKey {
+			maxKey = len(p[0])
+		}
+	}
+	for _, p := range pairs {
+		pad := maxKey - len(p[0]) + 1
+		fmt.Fprintf(b, "\t%s:%s%s,\n", p[0], strings.Repeat(" ", pad), p[1])
+	}
+}
+
+// friendlyName derives the external string form of a member by stripping
+// the enum type's name as a prefix and lowercasing what remains, e.g.
+// StatusPending -> "pending".
+func friendlyName(typeName, memberName string) string {
+	trimmed := strings.TrimPrefix(memberName, typeName)
+	if trimmed == "" {
+		trimmed = memberName
+	}
+	return strings.ToLower(trimmed)
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
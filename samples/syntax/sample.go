@@ -6,12 +6,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
 	"io"
 	"log"
 	"net/http"
-	"os"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 // Constants
@@ -33,7 +38,13 @@ type (
 )
 
 // Enum-like constants with iota
-type Status int
+//
+// String, ParseStatus, and the JSON (un)marshalers are generated from the
+// token:enum tag below; run `go generate` after adding or renaming a
+// variant. See status_string.go.
+//
+//go:generate go run github.com/HelgeSverre/token/cmd/token enumgen .
+type Status int // token:enum
 
 const (
 	StatusPending Status = iota
@@ -42,21 +53,6 @@ const (
 	StatusFailed
 )
 
-func (s Status) String() string {
-	switch s {
-	case StatusPending:
-		return "pending"
-	case StatusActive:
-		return "active"
-	case StatusCompleted:
-		return "completed"
-	case StatusFailed:
-		return "failed"
-	default:
-		return "unknown"
-	}
-}
-
 // Interfaces
 type Reader interface {
 	Read(p []byte) (n int, err error)
@@ -191,9 +187,15 @@ var (
 type ValidationError struct {
 	Field   string
 	Message string
+	File    string
+	Line    int
+	Col     int
 }
 
 func (e *ValidationError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("validation error on %s: %s (%s:%d:%d)", e.Field, e.Message, e.File, e.Line, e.Col)
+	}
 	return fmt.Sprintf("validation error on %s: %s", e.Field, e.Message)
 }
 
@@ -207,6 +209,461 @@ func ValidatePerson(p *Person) error {
 	return nil
 }
 
+// TokenKind is the stable vocabulary downstream highlighters map to CSS
+// classes. It collapses the much larger go/token.Token set down to the
+// handful of categories a syntax theme actually cares about.
+type TokenKind string
+
+const (
+	KindKeyword       TokenKind = "keyword"
+	KindIdent         TokenKind = "ident"
+	KindLiteralString TokenKind = "literal-string"
+	KindLiteralNumber TokenKind = "literal-number"
+	KindComment       TokenKind = "comment"
+	KindOperator      TokenKind = "operator"
+	KindPunctuation   TokenKind = "punctuation"
+	KindWhitespace    TokenKind = "whitespace"
+)
+
+// Token is a single span of source text, positioned for both line/column
+// display and byte-offset based highlighting.
+type Token struct {
+	Kind   TokenKind `json:"kind"`
+	Text   string    `json:"text"`
+	Line   int       `json:"line"`
+	Col    int       `json:"col"`
+	Offset int       `json:"offset"`
+	Length int       `json:"length"`
+}
+
+// classifyToken collapses a go/token.Token into our stable TokenKind
+// vocabulary.
+func classifyToken(tok token.Token) TokenKind {
+	switch {
+	case tok.IsKeyword():
+		return KindKeyword
+	case tok == token.IDENT:
+		return KindIdent
+	case tok == token.STRING, tok == token.CHAR:
+		return KindLiteralString
+	case tok == token.INT, tok == token.FLOAT, tok == token.IMAG:
+		return KindLiteralNumber
+	case tok == token.COMMENT:
+		return KindComment
+	case tok.IsOperator():
+		return KindOperator
+	default:
+		return KindPunctuation
+	}
+}
+
+// tokenizeSource scans Go source into Tokens, optionally including comments
+// and whitespace that go/scanner normally skips over.
+func tokenizeSource(src []byte, includeComments, includeWhitespace bool) ([]Token, error) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("source.go", fset.Base(), len(src))
+
+	var errs scanner.ErrorList
+	var s scanner.Scanner
+	s.Init(file, src, func(pos token.Position, msg string) {
+		errs.Add(pos, msg)
+	}, scanner.ScanComments)
+
+	tokens := make([]Token, 0)
+	prevEnd := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		offset := file.Offset(pos)
+
+		if includeWhitespace && offset > prevEnd {
+			if gap := src[prevEnd:offset]; strings.TrimSpace(string(gap)) == "" {
+				gapPos := file.Position(file.Pos(prevEnd))
+				tokens = append(tokens, Token{
+					Kind:   KindWhitespace,
+					Text:   string(gap),
+					Line:   gapPos.Line,
+					Col:    gapPos.Column,
+					Offset: prevEnd,
+					Length: len(gap),
+				})
+			}
+		}
+
+		text := lit
+		if text == "" {
+			text = tok.String()
+		}
+		kind := classifyToken(tok)
+		prevEnd = offset + len(text)
+
+		if kind == KindComment && !includeComments {
+			continue
+		}
+
+		p := file.Position(pos)
+		tokens = append(tokens, Token{
+			Kind:   kind,
+			Text:   text,
+			Line:   p.Line,
+			Col:    p.Column,
+			Offset: offset,
+			Length: len(text),
+		})
+	}
+
+	if len(errs) > 0 {
+		first := errs[0]
+		return nil, &ValidationError{
+			Field:   "source",
+			Message: first.Msg,
+			File:    "source.go",
+			Line:    first.Pos.Line,
+			Col:     first.Pos.Column,
+		}
+	}
+
+	return tokens, nil
+}
+
+// handleTokenize scans a POSTed Go source file and returns its token spans
+// as JSON. The ?include=comments,whitespace query param opts into token
+// kinds that are normally skipped.
+func handleTokenize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	src, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	include := strings.Split(r.URL.Query().Get("include"), ",")
+	var includeComments, includeWhitespace bool
+	for _, opt := range include {
+		switch strings.TrimSpace(opt) {
+		case "comments":
+			includeComments = true
+		case "whitespace":
+			includeWhitespace = true
+		}
+	}
+
+	tokens, err := tokenizeSource(src, includeComments, includeWhitespace)
+	if err != nil {
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			http.Error(w, verr.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// Tokenizer scans Go source and streams the resulting Tokens over a channel.
+// It keeps a single FileSet across calls, so tokenizing many small inputs
+// with Reset skips the per-call FileSet and Tokenizer setup that the
+// package-level Tokenize convenience function pays on every call.
+type Tokenizer struct {
+	fset *token.FileSet
+	src  []byte
+}
+
+// NewTokenizer returns a ready-to-use Tokenizer. Call Reset before Tokens.
+func NewTokenizer() *Tokenizer {
+	return &Tokenizer{fset: token.NewFileSet()}
+}
+
+// Reset discards any previously scanned source and primes the Tokenizer to
+// scan src, reusing the Tokenizer's FileSet across calls.
+func (t *Tokenizer) Reset(src io.Reader) error {
+	buf, err := io.ReadAll(src)
+	if err != nil {
+		return fmt.Errorf("reading source: %w", err)
+	}
+	t.src = buf
+	return nil
+}
+
+// Tokens scans the Tokenizer's current source and streams Token values on
+// the returned channel, closing it on EOF. Scanner errors are sent on the
+// error channel. Every send honours ctx.Done() so callers can cancel long
+// tokenizations.
+func (t *Tokenizer) Tokens(ctx context.Context) (<-chan Token, <-chan error) {
+	out := make(chan Token)
+	errc := make(chan error, 1)
+	file := t.fset.AddFile("source.go", t.fset.Base(), len(t.src))
+
+	go func() {
+		defer close(out)
+		defer close(errc)
+
+		var errs scanner.ErrorList
+		var s scanner.Scanner
+		s.Init(file, t.src, func(pos token.Position, msg string) {
+			errs.Add(pos, msg)
+		}, scanner.ScanComments)
+
+		for {
+			pos, tok, lit := s.Scan()
+			if tok == token.EOF {
+				break
+			}
+
+			text := lit
+			if text == "" {
+				text = tok.String()
+			}
+			p := file.Position(pos)
+
+			tkn := Token{
+				Kind:   classifyToken(tok),
+				Text:   text,
+				Line:   p.Line,
+				Col:    p.Column,
+				Offset: file.Offset(pos),
+				Length: len(text),
+			}
+
+			select {
+			case out <- tkn:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+
+		if len(errs) > 0 {
+			first := errs[0]
+			errc <- &ValidationError{
+				Field:   "source",
+				Message: first.Msg,
+				File:    "source.go",
+				Line:    first.Pos.Line,
+				Col:     first.Pos.Column,
+			}
+		}
+	}()
+
+	return out, errc
+}
+
+// Tokenize is a convenience wrapper around a one-off Tokenizer. Callers
+// tokenizing many small inputs should instead keep a Tokenizer around and
+// call Reset to amortize allocations.
+func Tokenize(ctx context.Context, src io.Reader) (<-chan Token, <-chan error) {
+	t := NewTokenizer()
+	if err := t.Reset(src); err != nil {
+		out := make(chan Token)
+		errc := make(chan error, 1)
+		close(out)
+		errc <- err
+		close(errc)
+		return out, errc
+	}
+	return t.Tokens(ctx)
+}
+
+// wsUpgrader upgrades /tokenize/ws connections. CheckOrigin is left nil, so
+// the upgrader's default same-origin check applies: a request whose Origin
+// header names a host other than the request's Host is rejected.
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+}
+
+// editRange is a half-open [Start, End) byte range into a buffer's source.
+type editRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// wsMessage is one frame of the incremental editing protocol spoken over
+// /tokenize/ws: "open" seeds a buffer's full text, "edit" replaces a byte
+// range within it.
+type wsMessage struct {
+	Type  string     `json:"type"`
+	ID    string     `json:"id"`
+	Text  string     `json:"text,omitempty"`
+	Range *editRange `json:"range,omitempty"`
+}
+
+// wsTokenResponse is sent back for every processed "open"/"edit" message.
+type wsTokenResponse struct {
+	Type   string  `json:"type"`
+	ID     string  `json:"id"`
+	Tokens []Token `json:"tokens,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// bufferStore holds the last-parsed source for each live editor buffer,
+// keyed by the client-chosen buffer id.
+type bufferStore struct {
+	mu      sync.RWMutex
+	sources map[string]string
+}
+
+func newBufferStore() *bufferStore {
+	return &bufferStore{sources: make(map[string]string)}
+}
+
+func (b *bufferStore) open(id, text string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sources[id] = text
+}
+
+// edit applies rng as a replacement of text within the buffer's source and
+// returns the updated source. ok is false if the buffer or range is invalid.
+func (b *bufferStore) edit(id string, rng editRange, text string) (updated string, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	src, found := b.sources[id]
+	if !found || rng.Start < 0 || rng.Start > rng.End || rng.End > len(src) {
+		return "", false
+	}
+	updated = src[:rng.Start] + text + src[rng.End:]
+	b.sources[id] = updated
+	return updated, true
+}
+
+func (b *bufferStore) close(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.sources, id)
+}
+
+// tokenizeEnclosingDecl re-tokenizes only the top-level declaration
+// enclosing rng, falling back to the whole buffer when the source doesn't
+// parse or no declaration contains the edit.
+func tokenizeEnclosingDecl(id, src string, rng editRange) ([]Token, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, id, src, parser.AllErrors)
+	if err != nil {
+		return tokenizeSource([]byte(src), true, false)
+	}
+
+	tfile := fset.File(file.Pos())
+	for _, decl := range file.Decls {
+		start := fset.Position(decl.Pos()).Offset
+		end := fset.Position(decl.End()).Offset
+		if rng.Start < start || rng.End > end {
+			continue
+		}
+		tokens, err := tokenizeSource([]byte(src[start:end]), true, false)
+		if err != nil {
+			return tokenizeSource([]byte(src), true, false)
+		}
+		for i := range tokens {
+			abs := tokens[i].Offset + start
+			pos := fset.Position(tfile.Pos(abs))
+			tokens[i].Offset = abs
+			tokens[i].Line = pos.Line
+			tokens[i].Col = pos.Column
+		}
+		return tokens, nil
+	}
+
+	return tokenizeSource([]byte(src), true, false)
+}
+
+// handleTokenizeWS upgrades to a WebSocket and serves the incremental
+// editing protocol: "open" seeds a buffer's full text, "edit" applies a
+// byte-range replacement. Each message gets back the tokens for the
+// affected region only, falling back to the whole file when a minimal
+// region can't be determined. The connection's buffers are dropped on
+// disconnect.
+func handleTokenizeWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("tokenize/ws upgrade: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	buffers := newBufferStore()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		var (
+			src  string
+			resp wsTokenResponse
+		)
+		resp.Type, resp.ID = "tokens", msg.ID
+
+		switch msg.Type {
+		case "open":
+			buffers.open(msg.ID, msg.Text)
+			src = msg.Text
+		case "edit":
+			if msg.Range == nil {
+				resp.Type, resp.Error = "error", "edit message missing range"
+				conn.WriteJSON(resp)
+				continue
+			}
+			updated, ok := buffers.edit(msg.ID, *msg.Range, msg.Text)
+			if !ok {
+				resp.Type, resp.Error = "error", "unknown buffer or invalid range"
+				conn.WriteJSON(resp)
+				continue
+			}
+			src = updated
+		case "close":
+			buffers.close(msg.ID)
+			continue
+		default:
+			resp.Type, resp.Error = "error", fmt.Sprintf("unknown message type %q", msg.Type)
+			conn.WriteJSON(resp)
+			continue
+		}
+
+		var (
+			tokens []Token
+			tokErr error
+		)
+		if msg.Type == "edit" {
+			// msg.Range is in pre-edit coordinates; in src the replacement
+			// text spans [Start, Start+len(Text)).
+			affected := editRange{Start: msg.Range.Start, End: msg.Range.Start + len(msg.Text)}
+			tokens, tokErr = tokenizeEnclosingDecl(msg.ID, src, affected)
+		} else {
+			tokens, tokErr = tokenizeSource([]byte(src), true, false)
+		}
+		if tokErr != nil {
+			resp.Type, resp.Error = "error", tokErr.Error()
+			conn.WriteJSON(resp)
+			continue
+		}
+
+		resp.Tokens = tokens
+		if err := conn.WriteJSON(resp); err != nil {
+			return
+		}
+	}
+}
+
 // HTTP handler
 func handleUsers(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -360,6 +817,8 @@ func main() {
 
 	// Start HTTP server
 	http.HandleFunc("/users", handleUsers)
+	http.HandleFunc("/tokenize", handleTokenize)
+	http.HandleFunc("/tokenize/ws", handleTokenizeWS)
 	log.Println("Starting server on :8080")
 	if err := http.ListenAndServe(":8080", nil); err != nil {
 		log.Fatal(err)